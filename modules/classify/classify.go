@@ -0,0 +1,116 @@
+// Package classify tags pages a crawl discovers with labels such as
+// marketplace, forum, or login, based on DOM features gathered at fetch
+// time, so large crawls can be filtered down to the subsets an operator
+// actually cares about.
+package classify
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/DedSecInside/TorBot/modules/crawler"
+	"gopkg.in/yaml.v3"
+)
+
+// Page is the page type a Classifier inspects.
+type Page = crawler.Page
+
+// Tag labels a page with the kind of content it appears to hold.
+type Tag = crawler.Tag
+
+// Classifier assigns tags to a fetched page.
+type Classifier = crawler.Classifier
+
+// Well-known tags RuleClassifier assigns out of the box.
+const (
+	TagMarketplace Tag = "marketplace"
+	TagForum       Tag = "forum"
+	TagPaste       Tag = "paste"
+	TagIndex       Tag = "index"
+	TagLogin       Tag = "login"
+	TagCaptcha     Tag = "captcha"
+	TagDown        Tag = "down"
+)
+
+// RuleConfig is the keyword list a RuleClassifier matches against a
+// page's title, meta keywords, and visible text. It is usually loaded
+// from YAML via LoadRuleConfig.
+type RuleConfig struct {
+	Keywords map[Tag][]string `yaml:"keywords"`
+}
+
+// LoadRuleConfig reads a RuleConfig from a YAML file shaped like:
+//
+//	keywords:
+//	  marketplace: [checkout, "add to cart", vendor]
+//	  forum:       [thread, reply, moderator]
+func LoadRuleConfig(path string) (*RuleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg RuleConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// defaultRuleConfig is used when NewRuleClassifier is given a nil config,
+// so a RuleClassifier is usable without a YAML file on disk.
+func defaultRuleConfig() *RuleConfig {
+	return &RuleConfig{Keywords: map[Tag][]string{
+		TagMarketplace: {"add to cart", "checkout", "vendor", "escrow", "shipping"},
+		TagForum:       {"thread", "reply", "moderator", "forum", "topic"},
+		TagPaste:       {"paste", "raw text", "syntax highlighting"},
+		TagIndex:       {"index of", "directory listing"},
+	}}
+}
+
+// RuleClassifier tags pages by matching keywords against their title,
+// meta keywords, and visible text, and by inspecting form fields and
+// HTTP status for logins, captchas, and dead hosts.
+type RuleClassifier struct {
+	cfg *RuleConfig
+}
+
+// NewRuleClassifier returns a RuleClassifier using cfg's keyword lists.
+// A nil cfg falls back to a small built-in keyword list.
+func NewRuleClassifier(cfg *RuleConfig) *RuleClassifier {
+	if cfg == nil {
+		cfg = defaultRuleConfig()
+	}
+	return &RuleClassifier{cfg: cfg}
+}
+
+// Classify implements Classifier.
+func (rc *RuleClassifier) Classify(ctx context.Context, page Page) ([]Tag, error) {
+	var tags []Tag
+
+	if page.Status == 0 || page.Status >= 500 {
+		return []Tag{TagDown}, nil
+	}
+
+	for _, field := range page.FormFields {
+		field = strings.ToLower(field)
+		switch {
+		case strings.Contains(field, "captcha"):
+			tags = append(tags, TagCaptcha)
+		case strings.Contains(field, "password"):
+			tags = append(tags, TagLogin)
+		}
+	}
+
+	haystack := strings.ToLower(page.Title + " " + page.MetaKeywords + " " + page.Text)
+	for tag, keywords := range rc.cfg.Keywords {
+		for _, keyword := range keywords {
+			if strings.Contains(haystack, strings.ToLower(keyword)) {
+				tags = append(tags, tag)
+				break
+			}
+		}
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,57 @@
+package classify
+
+import "testing"
+
+func TestRuleClassifierClassify(t *testing.T) {
+	rc := NewRuleClassifier(nil)
+
+	down, err := rc.Classify(nil, Page{Status: 503})
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if len(down) != 1 || down[0] != TagDown {
+		t.Errorf("Classify(status 503) = %v, want [%v]", down, TagDown)
+	}
+
+	login, err := rc.Classify(nil, Page{Status: 200, FormFields: []string{"username", "password"}})
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if !containsTag(login, TagLogin) {
+		t.Errorf("Classify(login form) = %v, want to contain %v", login, TagLogin)
+	}
+
+	market, err := rc.Classify(nil, Page{Status: 200, Title: "Vendor listings", Text: "add to cart now"})
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if !containsTag(market, TagMarketplace) {
+		t.Errorf("Classify(marketplace copy) = %v, want to contain %v", market, TagMarketplace)
+	}
+}
+
+func containsTag(tags []Tag, want Tag) bool {
+	for _, tag := range tags {
+		if tag == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetectLanguage(t *testing.T) {
+	table := []struct {
+		text string
+		want string
+	}{
+		{"the quick fox and the hound went with you for your dinner", "en"},
+		{"le chat et les chiens vous attendent pour le repas", "fr"},
+		{"hi", ""},
+	}
+
+	for _, tc := range table {
+		if got := DetectLanguage(tc.text); got != tc.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
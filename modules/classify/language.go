@@ -0,0 +1,36 @@
+package classify
+
+import "strings"
+
+// stopwords are the handful of common function words distinctive enough,
+// per language, to tell short page text apart without pulling in a full
+// n-gram language-identification library.
+var stopwords = map[string][]string{
+	"en": {" the ", " and ", " you ", " your ", " with ", " for "},
+	"de": {" der ", " und ", " die ", " nicht ", " mit ", " sie "},
+	"fr": {" le ", " la ", " les ", " et ", " vous ", " pour "},
+	"es": {" el ", " los ", " las ", " y ", " para ", " con "},
+	"ru": {"и", "не", "что", "это", "вы"},
+}
+
+// DetectLanguage guesses text's language by counting stopword hits per
+// language and returning the best-scoring one. It returns "" when text
+// is too short or no language scores above zero.
+func DetectLanguage(text string) string {
+	if len(strings.TrimSpace(text)) < 8 {
+		return ""
+	}
+
+	padded := " " + strings.ToLower(text) + " "
+	best, bestScore := "", 0
+	for lang, words := range stopwords {
+		score := 0
+		for _, word := range words {
+			score += strings.Count(padded, word)
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
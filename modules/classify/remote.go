@@ -0,0 +1,75 @@
+package classify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// RemoteClassifier delegates classification to a user-supplied HTTP
+// endpoint, e.g. a hosted ML model, by POSTing the page's text and
+// reading back the tags it was classified with.
+type RemoteClassifier struct {
+	// Endpoint is the URL RemoteClassifier POSTs each page to.
+	Endpoint string
+	// Client performs the request; http.DefaultClient is used if nil.
+	Client *http.Client
+}
+
+// NewRemoteClassifier returns a RemoteClassifier posting to endpoint. A
+// nil client uses http.DefaultClient.
+func NewRemoteClassifier(endpoint string, client *http.Client) *RemoteClassifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RemoteClassifier{Endpoint: endpoint, Client: client}
+}
+
+type remoteClassifyRequest struct {
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Text   string `json:"text"`
+	Status int    `json:"status"`
+}
+
+type remoteClassifyResponse struct {
+	Tags []Tag `json:"tags"`
+}
+
+// Classify implements Classifier by POSTing page to rc.Endpoint as JSON
+// and decoding the tags from the response body.
+func (rc *RemoteClassifier) Classify(ctx context.Context, page Page) ([]Tag, error) {
+	body, err := json.Marshal(remoteClassifyRequest{
+		URL:    page.URL,
+		Title:  page.Title,
+		Text:   page.Text,
+		Status: page.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rc.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := rc.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("classify: remote endpoint returned %v", resp.Status)
+	}
+
+	var out remoteClassifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.Tags, nil
+}
@@ -0,0 +1,601 @@
+// Package crawler performs a bounded, breadth-first crawl of .onion sites,
+// replacing a one-page fetch with a worker pool that walks the discovered
+// link graph under depth, rate, and page-count limits.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	urllib "net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/time/rate"
+)
+
+var onionPattern = regexp.MustCompile(`^https?:\/\/(www\.)?([a-z0-9]+)\.onion\/?(.*)`)
+
+// Page is a single fetched page and the metadata gathered about it.
+type Page struct {
+	URL         string
+	Title       string
+	ContentType string
+	Status      int
+	Depth       int
+	Latency     time.Duration
+
+	// Text is the page's visible text content, capped at maxPageText
+	// bytes, kept around for classification and language detection.
+	Text string
+	// Headers is the response's HTTP headers.
+	Headers http.Header
+	// MetaKeywords is the content of a <meta name="keywords"> or
+	// <meta name="description"> tag, whichever is present.
+	MetaKeywords string
+	// FormFields lists the name attributes of every <input> found on
+	// the page, a signal for detecting login and captcha forms.
+	FormFields []string
+
+	// Tags is set by Crawler.Classifier, if configured.
+	Tags []Tag
+	// Language is set by Crawler.DetectLanguage, if configured.
+	Language string
+}
+
+// Tag labels a page with the kind of content it appears to hold, e.g.
+// "marketplace" or "login".
+type Tag string
+
+// Edge is a discovered hyperlink between two pages.
+type Edge struct {
+	From   string
+	To     string
+	Status int
+}
+
+// Result is the subgraph discovered by a crawl.
+type Result struct {
+	Pages []Page
+	Edges []Edge
+}
+
+// EdgeMeta carries the extra information known about an edge at the time
+// it is emitted.
+type EdgeMeta struct {
+	Status  int
+	Latency time.Duration
+}
+
+// Emitter receives a crawl's pages and edges as they are discovered,
+// letting a caller stream results out in a serialized format instead of
+// waiting for Run to return the full Result. Implementations must be
+// safe for concurrent use, since a Crawler may call EmitPage/EmitEdge
+// from multiple workers at once.
+type Emitter interface {
+	EmitPage(Page) error
+	EmitEdge(from, to string, meta EdgeMeta) error
+}
+
+// Classifier assigns tags to a fetched page, run once per page after it
+// is fetched.
+type Classifier interface {
+	Classify(ctx context.Context, page Page) ([]Tag, error)
+}
+
+// Job is a unit of crawl work: a URL queued at a given depth.
+type Job struct {
+	URL   string
+	Depth int
+}
+
+// Store persists a crawl's frontier and visited set so that a long
+// crawl can resume from where it left off after a restart, instead of
+// dropping whatever work was in flight.
+type Store interface {
+	Enqueue(url string, depth int) error
+	Dequeue() (Job, bool, error)
+	MarkVisited(url string, page Page) error
+	IsVisited(url string) (bool, error)
+	Checkpoint() error
+}
+
+// Crawler performs a breadth-first crawl restricted to .onion hosts.
+type Crawler struct {
+	// Client performs the HTTP requests; callers typically pass a client
+	// routed through Tor, e.g. an (*torctl.Instance).HTTPClient(). It is
+	// also the fallback used when ClientFor is nil or errors.
+	Client *http.Client
+
+	// ClientFor, when set, is asked for the client to use for a given
+	// host before every fetch, e.g. a (*torctl.CircuitManager).Client
+	// that hands back a per-host isolated circuit.
+	ClientFor func(ctx context.Context, host string) (*http.Client, error)
+
+	MaxDepth       int
+	Concurrency    int
+	PerHostRate    float64
+	RespectRobots  bool
+	AllowedSchemes []string
+	MaxPages       int
+
+	// OnPage, when set, is called as each page is fetched.
+	OnPage func(Page)
+
+	// Emitter, when set, receives every page and edge as it is
+	// discovered, in addition to OnPage and the Result returned by Run.
+	Emitter Emitter
+
+	// Classifier, when set, tags every fetched page before it is
+	// recorded or emitted.
+	Classifier Classifier
+	// IncludeTags restricts recorded pages to those carrying at least
+	// one of these tags, once Classifier has run. A nil or empty slice
+	// records every page. Pages are still crawled for their outbound
+	// links regardless of tag.
+	IncludeTags []Tag
+	// DetectLanguage, when set, is used to populate Page.Language from
+	// the page's extracted text.
+	DetectLanguage func(text string) string
+
+	// Store, when set, makes Run durable: every queued URL and fetched
+	// page is mirrored to it, a prior run's frontier is resumed on
+	// startup, and it is checkpointed periodically and on SIGINT rather
+	// than dropping in-flight work.
+	Store Store
+	// CheckpointInterval is how often Run checkpoints Store while it is
+	// running. Zero only checkpoints on SIGINT and when Run returns.
+	CheckpointInterval time.Duration
+
+	limiters sync.Map // host -> *rate.Limiter
+	robots   sync.Map // host -> *robotsRules
+	visited  sync.Map // normalized url -> struct{}
+}
+
+// Run walks the link graph reachable from seeds, fanning work out across
+// c.Concurrency workers, and returns the discovered subgraph.
+func (c *Crawler) Run(ctx context.Context, seeds []string) (*Result, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("crawler: Client must be set")
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 4
+	}
+	if c.MaxDepth <= 0 {
+		c.MaxDepth = 1
+	}
+	if len(c.AllowedSchemes) == 0 {
+		c.AllowedSchemes = []string{"http", "https"}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &Result{}
+	var mu sync.Mutex
+	var pageCount int32
+
+	frontier := newFrontier()
+
+	if c.Store != nil {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		defer signal.Stop(sig)
+		go func() {
+			select {
+			case <-sig:
+				c.Store.Checkpoint()
+				cancel()
+				frontier.close()
+			case <-ctx.Done():
+			}
+		}()
+
+		if c.CheckpointInterval > 0 {
+			ticker := time.NewTicker(c.CheckpointInterval)
+			defer ticker.Stop()
+			go func() {
+				for {
+					select {
+					case <-ticker.C:
+						c.Store.Checkpoint()
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		defer c.Store.Checkpoint()
+	}
+
+	var pending int32
+	resumed := false
+	if c.Store != nil {
+		var requeue []Job
+		for {
+			j, ok, err := c.Store.Dequeue()
+			if err != nil || !ok {
+				break
+			}
+			resumed = true
+			key := normalizeURL(j.URL)
+			if visited, err := c.Store.IsVisited(key); err == nil && visited {
+				continue
+			}
+			if _, loaded := c.visited.LoadOrStore(key, struct{}{}); loaded {
+				continue
+			}
+			pending++
+			frontier.push(j)
+			requeue = append(requeue, j)
+		}
+		// Dequeue removed the resumed jobs from the Store; put them back so
+		// they survive another interruption before they are fetched.
+		for _, j := range requeue {
+			c.Store.Enqueue(j.URL, j.Depth)
+		}
+	}
+	if !resumed {
+		for _, seed := range seeds {
+			if c.markVisited(seed) {
+				continue
+			}
+			pending++
+			frontier.push(Job{URL: seed, Depth: 0})
+			if c.Store != nil {
+				c.Store.Enqueue(seed, 0)
+			}
+		}
+	}
+	if pending == 0 {
+		frontier.close()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j, ok := frontier.pop()
+				if !ok {
+					return
+				}
+				children := c.visit(ctx, j, &mu, result, &pageCount)
+				if c.MaxPages > 0 && atomic.LoadInt32(&pageCount) >= int32(c.MaxPages) {
+					atomic.AddInt32(&pending, -1)
+					if atomic.LoadInt32(&pending) == 0 {
+						frontier.close()
+					}
+					continue
+				}
+				atomic.AddInt32(&pending, int32(len(children)))
+				for _, child := range children {
+					frontier.push(child)
+					if c.Store != nil {
+						c.Store.Enqueue(child.URL, child.Depth)
+					}
+				}
+				if atomic.AddInt32(&pending, -1) == 0 {
+					frontier.close()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result, ctx.Err()
+}
+
+// visit fetches a single job's URL, records the page and its outbound
+// edges, and returns the child jobs discovered on it.
+func (c *Crawler) visit(ctx context.Context, j Job, mu *sync.Mutex, result *Result, pageCount *int32) []Job {
+	host := hostOf(j.URL)
+	if c.PerHostRate > 0 {
+		if err := c.limiterFor(host).Wait(ctx); err != nil {
+			return nil
+		}
+	}
+	if c.RespectRobots && !c.robotsAllow(ctx, j.URL) {
+		return nil
+	}
+
+	client := c.Client
+	if c.ClientFor != nil {
+		if perHost, err := c.ClientFor(ctx, host); err == nil {
+			client = perHost
+		}
+	}
+
+	start := time.Now()
+	page, links, err := fetch(ctx, client, j.URL)
+	page.Depth = j.Depth
+	page.Latency = time.Since(start)
+
+	if c.Classifier != nil {
+		if tags, cerr := c.Classifier.Classify(ctx, page); cerr == nil {
+			page.Tags = tags
+		}
+	}
+	if c.DetectLanguage != nil {
+		page.Language = c.DetectLanguage(page.Text)
+	}
+	if c.Store != nil {
+		c.Store.MarkVisited(normalizeURL(j.URL), page)
+	}
+
+	if c.includeTagsMatch(page.Tags) {
+		mu.Lock()
+		result.Pages = append(result.Pages, page)
+		mu.Unlock()
+		if c.OnPage != nil {
+			c.OnPage(page)
+		}
+		if c.Emitter != nil {
+			c.Emitter.EmitPage(page)
+		}
+	}
+	atomic.AddInt32(pageCount, 1)
+
+	if err != nil || j.Depth >= c.MaxDepth {
+		return nil
+	}
+
+	var children []Job
+	for _, link := range links {
+		// The destination isn't fetched until it's popped off the
+		// frontier, so a HEAD request is the only way to know its status
+		// at edge-discovery time without fetching every link twice.
+		status, _ := checkURL(client, link)
+		mu.Lock()
+		result.Edges = append(result.Edges, Edge{From: j.URL, To: link, Status: status})
+		mu.Unlock()
+		if c.Emitter != nil {
+			c.Emitter.EmitEdge(j.URL, link, EdgeMeta{Status: status, Latency: page.Latency})
+		}
+
+		if !isOnionURL(link) || !c.schemeAllowed(link) || c.markVisited(link) {
+			continue
+		}
+		children = append(children, Job{URL: link, Depth: j.Depth + 1})
+	}
+	return children
+}
+
+func (c *Crawler) schemeAllowed(rawURL string) bool {
+	u, err := urllib.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range c.AllowedSchemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// includeTagsMatch reports whether pageTags satisfies c.IncludeTags: true
+// when no filter is configured, or when at least one tag is shared.
+func (c *Crawler) includeTagsMatch(pageTags []Tag) bool {
+	if len(c.IncludeTags) == 0 {
+		return true
+	}
+	for _, want := range c.IncludeTags {
+		for _, got := range pageTags {
+			if want == got {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markVisited records url as visited and reports whether it had already
+// been seen, using a normalized form as the dedup key. When a Store is
+// configured, it is also consulted so a resumed crawl doesn't re-queue
+// URLs a prior run already fetched.
+func (c *Crawler) markVisited(rawURL string) bool {
+	key := normalizeURL(rawURL)
+	if _, loaded := c.visited.Load(key); loaded {
+		return true
+	}
+	if c.Store != nil {
+		if visited, err := c.Store.IsVisited(key); err == nil && visited {
+			c.visited.Store(key, struct{}{})
+			return true
+		}
+	}
+	_, loaded := c.visited.LoadOrStore(key, struct{}{})
+	return loaded
+}
+
+func (c *Crawler) limiterFor(host string) *rate.Limiter {
+	if l, ok := c.limiters.Load(host); ok {
+		return l.(*rate.Limiter)
+	}
+	limiter := rate.NewLimiter(rate.Limit(c.PerHostRate), 1)
+	actual, _ := c.limiters.LoadOrStore(host, limiter)
+	return actual.(*rate.Limiter)
+}
+
+func normalizeURL(rawURL string) string {
+	u, err := urllib.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+func hostOf(rawURL string) string {
+	u, err := urllib.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// isOnionURL reports whether rawURL points at a .onion host.
+func isOnionURL(rawURL string) bool {
+	return onionPattern.MatchString(rawURL)
+}
+
+type netClient interface {
+	Head(string) (*http.Response, error)
+}
+
+// checkURL performs a HEAD request and returns the link's HTTP status
+// code, without pulling in the full page body. It is kept as a
+// standalone building block so tools that only need reachability (rather
+// than a full crawl) can use it directly.
+func checkURL(client netClient, target string) (int, error) {
+	resp, err := client.Head(target)
+	if err != nil {
+		return 0, err
+	}
+	return resp.StatusCode, nil
+}
+
+// parseAttrs extracts href values from a tag's attributes.
+func parseAttrs(attributes []html.Attribute) []string {
+	var found []string
+	for _, attr := range attributes {
+		if attr.Key == "href" {
+			found = append(found, attr.Val)
+		}
+	}
+	return found
+}
+
+// maxPageText caps how much visible text a fetch keeps around for
+// classification and language detection, so a single oversized page
+// can't blow up memory during a long crawl.
+const maxPageText = 4096
+
+// fetch GETs target and parses its title, content type, outbound links,
+// and the DOM features (meta keywords, form fields, visible text) that
+// classify.RuleClassifier and language detection key off of.
+func fetch(ctx context.Context, client *http.Client, target string) (Page, []string, error) {
+	page := Page{URL: target}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return page, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return page, nil, err
+	}
+	defer resp.Body.Close()
+
+	page.Status = resp.StatusCode
+	page.ContentType = resp.Header.Get("Content-Type")
+	page.Headers = resp.Header
+
+	var links []string
+	var text strings.Builder
+	tokenizer := html.NewTokenizer(resp.Body)
+	for notEnd := true; notEnd; {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			notEnd = false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "a":
+				links = append(links, parseAttrs(token.Attr)...)
+			case "title":
+				if tokenizer.Next() == html.TextToken {
+					page.Title = tokenizer.Token().Data
+				}
+			case "meta":
+				if name, content := metaNameContent(token.Attr); name == "keywords" || name == "description" {
+					page.MetaKeywords = content
+				}
+			case "input":
+				for _, attr := range token.Attr {
+					if attr.Key == "name" {
+						page.FormFields = append(page.FormFields, attr.Val)
+					}
+				}
+			}
+		case html.TextToken:
+			if text.Len() < maxPageText {
+				text.WriteString(strings.TrimSpace(tokenizer.Token().Data))
+				text.WriteByte(' ')
+			}
+		}
+	}
+	page.Text = text.String()
+	if len(page.Text) > maxPageText {
+		page.Text = page.Text[:maxPageText]
+	}
+	return page, links, nil
+}
+
+// metaNameContent extracts the name and content attributes of a <meta> tag.
+func metaNameContent(attributes []html.Attribute) (name, content string) {
+	for _, attr := range attributes {
+		switch attr.Key {
+		case "name":
+			name = strings.ToLower(attr.Val)
+		case "content":
+			content = attr.Val
+		}
+	}
+	return name, content
+}
+
+// frontier is an unbounded FIFO queue of jobs safe for concurrent
+// producers and consumers, used instead of a fixed-size channel so that
+// workers can never deadlock while feeding their own discoveries back in.
+type frontier struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []Job
+	closed bool
+}
+
+func newFrontier() *frontier {
+	f := &frontier{}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *frontier) push(j Job) {
+	f.mu.Lock()
+	f.items = append(f.items, j)
+	f.cond.Signal()
+	f.mu.Unlock()
+}
+
+func (f *frontier) pop() (Job, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.items) == 0 && !f.closed {
+		f.cond.Wait()
+	}
+	if len(f.items) == 0 {
+		return Job{}, false
+	}
+	j := f.items[0]
+	f.items = f.items[1:]
+	return j, true
+}
+
+func (f *frontier) close() {
+	f.mu.Lock()
+	f.closed = true
+	f.cond.Broadcast()
+	f.mu.Unlock()
+}
@@ -0,0 +1,217 @@
+package crawler
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsOnionURL(t *testing.T) {
+	table := []struct {
+		url string
+		ans bool
+	}{
+		{"https://www.google.com", false},
+		{"https://www.facebook.com", false},
+		{"http://torlinkbgs6aabns.onion/", true},
+		{"https://www.propub3r6espa33w.onion", true},
+		{"asfasf", false},
+		{"www.twitter.com", false},
+		{"www.facebookcorewwi.onion", false},
+		{"ftp://asfasdf.lkjkl", false},
+	}
+
+	for _, testData := range table {
+		if testData.ans != isOnionURL(testData.url) {
+			t.Errorf("%v received the value %v for being a valid onion url.",
+				testData.url,
+				isOnionURL(testData.url))
+		}
+	}
+}
+
+type mockClient struct {
+	mockResponse map[string]*http.Response
+	Error        error
+}
+
+func (client mockClient) Head(url string) (*http.Response, error) {
+	return client.mockResponse[url], client.Error
+}
+
+func TestCheckURL(t *testing.T) {
+	var err error
+
+	for i := 0; i < 10; i++ {
+		stCode := rand.Intn(600)
+		if stCode > 400 {
+			err = http.ErrNoLocation
+		} else {
+			err = nil
+		}
+		client := mockClient{map[string]*http.Response{"url": {StatusCode: stCode}}, err}
+
+		status, gotErr := checkURL(client, "url")
+
+		switch {
+		case err != nil:
+			if gotErr == nil {
+				t.Errorf("expected an error for status code %v, got none", stCode)
+			}
+		case status != stCode:
+			t.Errorf("checkURL returned status %v, want %v", status, stCode)
+		}
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	got := normalizeURL("http://torlinkbgs6aabns.onion#section")
+	want := "http://torlinkbgs6aabns.onion/"
+	if got != want {
+		t.Errorf("normalizeURL() = %v, want %v", got, want)
+	}
+}
+
+// roundTripFunc lets a test's http.Client redirect requests for a fake
+// .onion host to an httptest.Server, without the crawler itself having to
+// know its real address.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func onionTestClient(srv *httptest.Server) *http.Client {
+	return &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			out := req.Clone(req.Context())
+			out.URL.Scheme = "http"
+			out.URL.Host = strings.TrimPrefix(srv.URL, "http://")
+			return http.DefaultTransport.RoundTrip(out)
+		}),
+	}
+}
+
+func TestRunCrawlsAndRecordsEdgeStatus(t *testing.T) {
+	const seed = "http://abcxyz123.onion/"
+	const page2 = "http://abcxyz123.onion/page2"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="` + page2 + `">next</a></body></html>`))
+	})
+	mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>no links here</body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := &Crawler{
+		Client:      onionTestClient(srv),
+		MaxDepth:    1,
+		Concurrency: 2,
+	}
+
+	result, err := c.Run(context.Background(), []string{seed})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Pages) != 2 {
+		t.Fatalf("len(result.Pages) = %d, want 2", len(result.Pages))
+	}
+	if len(result.Edges) != 1 {
+		t.Fatalf("len(result.Edges) = %d, want 1", len(result.Edges))
+	}
+	if result.Edges[0].Status != http.StatusOK {
+		t.Errorf("result.Edges[0].Status = %d, want %d", result.Edges[0].Status, http.StatusOK)
+	}
+}
+
+// fakeStore is an in-memory Store used to test that visited state set by
+// one Crawler run is recognized by a later one.
+type fakeStore struct {
+	visited map[string]Page
+}
+
+func newFakeStore() *fakeStore { return &fakeStore{visited: make(map[string]Page)} }
+
+func (s *fakeStore) Enqueue(url string, depth int) error { return nil }
+
+func (s *fakeStore) Dequeue() (Job, bool, error) { return Job{}, false, nil }
+
+func (s *fakeStore) MarkVisited(url string, page Page) error {
+	s.visited[url] = page
+	return nil
+}
+
+func (s *fakeStore) IsVisited(url string) (bool, error) {
+	_, ok := s.visited[url]
+	return ok, nil
+}
+
+func (s *fakeStore) Checkpoint() error { return nil }
+
+func TestRunMarkVisitedUsesNormalizedKey(t *testing.T) {
+	const seed = "http://abcxyz123.onion"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>no links here</body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	st := newFakeStore()
+
+	first := &Crawler{Client: onionTestClient(srv), Store: st, MaxDepth: 1, Concurrency: 1}
+	result, err := first.Run(context.Background(), []string{seed})
+	if err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	if len(result.Pages) != 1 {
+		t.Fatalf("first Run(): len(result.Pages) = %d, want 1", len(result.Pages))
+	}
+
+	second := &Crawler{Client: onionTestClient(srv), Store: st, MaxDepth: 1, Concurrency: 1}
+	result, err = second.Run(context.Background(), []string{seed})
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if len(result.Pages) != 0 {
+		t.Errorf("second Run(): len(result.Pages) = %d, want 0 (seed already visited per Store)", len(result.Pages))
+	}
+}
+
+func TestRunRespectsMaxDepth(t *testing.T) {
+	const seed = "http://abcxyz123.onion/"
+	const page2 = "http://abcxyz123.onion/page2"
+	const page3 = "http://abcxyz123.onion/page3"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="` + page2 + `">next</a></body></html>`))
+	})
+	mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="` + page3 + `">next</a></body></html>`))
+	})
+	mux.HandleFunc("/page3", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>dead end</body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := &Crawler{
+		Client:      onionTestClient(srv),
+		MaxDepth:    1,
+		Concurrency: 2,
+	}
+
+	result, err := c.Run(context.Background(), []string{seed})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Pages) != 2 {
+		t.Fatalf("len(result.Pages) = %d, want 2 (page3 is past MaxDepth)", len(result.Pages))
+	}
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	urllib "net/url"
+
+	"github.com/temoto/robotstxt"
+)
+
+// robotsAllow reports whether rawURL may be fetched according to the
+// target host's robots.txt, fetching and caching it on first use.
+func (c *Crawler) robotsAllow(ctx context.Context, rawURL string) bool {
+	u, err := urllib.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+
+	rules, err := c.robotsFor(ctx, u)
+	if err != nil {
+		// Fail open: a host with unreachable or malformed robots.txt is
+		// treated as allowing everything, matching most crawlers' default.
+		return true
+	}
+	return rules.TestAgent(u.Path, "TorBot")
+}
+
+func (c *Crawler) robotsFor(ctx context.Context, u *urllib.URL) (*robotstxt.RobotsData, error) {
+	if cached, ok := c.robots.Load(u.Host); ok {
+		return cached.(*robotstxt.RobotsData), nil
+	}
+
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rules, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := c.robots.LoadOrStore(u.Host, rules)
+	return actual.(*robotstxt.RobotsData), nil
+}
@@ -3,119 +3,157 @@ package main
 import "C"
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"net/http"
-	urllib "net/url"
 	"os"
-	"os/signal"
-	"regexp"
 	"time"
 
-	"github.com/mgutz/ansi"
-	"golang.org/x/net/html"
+	"github.com/DedSecInside/TorBot/modules/classify"
+	"github.com/DedSecInside/TorBot/modules/crawler"
+	"github.com/DedSecInside/TorBot/modules/output"
+	"github.com/DedSecInside/TorBot/modules/store"
+	"github.com/DedSecInside/TorBot/modules/torctl"
 )
 
-// Checks for valid .onion domain names
-func validOnionURL(url string) bool {
-	pattern := `^https?:\/\/(www\.)?([a-z,A-Z,0-9]*)\.onion/?(.*)`
-	re := regexp.MustCompile(pattern)
-	return re.Match([]byte(url))
+// defaultCheckpointInterval is how often a resumable crawl flushes its
+// Store to disk while running, in addition to checkpointing on SIGINT and
+// when the crawl finishes.
+const defaultCheckpointInterval = 30 * time.Second
+
+// newTorConn bootstraps an embedded Tor instance rather than assuming a
+// system tor daemon is listening on a SOCKS port, and returns the Instance
+// backing it. addr/port are kept for backwards compatibility: when port is
+// non-empty, they pin the SocksPort the embedded instance listens on (addr
+// defaulting to "127.0.0.1"), so existing deployments that expect Tor
+// reachable at a fixed address keep working.
+func newTorConn(ctx context.Context, addr string, port string) (*torctl.Instance, error) {
+	cfg := torctl.Config{}
+	if port != "" {
+		if addr == "" {
+			addr = "127.0.0.1"
+		}
+		cfg.SocksPort = fmt.Sprintf("%s:%s", addr, port)
+	}
+	return torctl.Start(ctx, cfg)
 }
 
-type netClient interface {
-	Head(string) (*http.Response, error)
+// defaultCircuitPolicy spreads a crawl's requests across a fresh circuit
+// per host and rotates away from any circuit that starts failing or
+// getting rate-limited.
+var defaultCircuitPolicy = torctl.CircuitPolicy{
+	IsolatePerHost:        true,
+	RotateEvery:           20,
+	RotateOn:              []int{429, 503},
+	MaxFailuresPerCircuit: 3,
 }
 
-// Sends string to channel that contains a message that explains the
-// status of the url passed
-func checkURL(client netClient, url string, ch chan<- string) {
-	red := ansi.ColorFunc("red")
-	resp, err := client.Head(url)
-	if err == nil && resp.StatusCode < 400 {
-		ch <- fmt.Sprintf("%v is reachable.\n", url)
-	} else {
-		ch <- red(fmt.Sprintf("%v is not reachable.\n", url))
+// crawl bootstraps an embedded Tor instance and runs a single-depth
+// crawl of searchURL, emitting every page it visits through emitter. When
+// storePath is non-empty, the crawl is made resumable: its frontier and
+// visited set are checkpointed to a BoltDB file at that path, so a crawl
+// interrupted by SIGINT or a restart can pick back up where it left off.
+func crawl(searchURL string, addr string, port string, timeout int, emitter output.Emitter, storePath string) (*crawler.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(timeout))
+	defer cancel()
+
+	instance, err := newTorConn(ctx, addr, port)
+	if err != nil {
+		return nil, fmt.Errorf("starting embedded tor: %w", err)
 	}
-}
+	defer instance.Close()
 
-// Parses html attributes to find urls
-func parseAttrs(attributes []html.Attribute) []string {
-	var foundUrls = make([]string, 0)
-	for i := 0; i < len(attributes); i++ {
-		if attributes[i].Key == "href" && validOnionURL(attributes[i].Val) {
-			foundUrls = append(foundUrls, attributes[i].Val)
+	client, err := instance.HTTPClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("building tor http client: %w", err)
+	}
+
+	circuits := torctl.NewCircuitManager(instance, defaultCircuitPolicy)
+
+	c := &crawler.Crawler{
+		Client:         client,
+		ClientFor:      circuits.Client,
+		MaxDepth:       1,
+		Concurrency:    8,
+		PerHostRate:    1,
+		Emitter:        emitter,
+		Classifier:     classify.NewRuleClassifier(nil),
+		DetectLanguage: classify.DetectLanguage,
+	}
+
+	if storePath != "" {
+		st, err := store.Open(storePath)
+		if err != nil {
+			return nil, fmt.Errorf("opening crawl store: %w", err)
 		}
+		defer st.Close()
+		c.Store = st
+		c.CheckpointInterval = defaultCheckpointInterval
 	}
-	return foundUrls
+
+	return c.Run(ctx, []string{searchURL})
 }
 
-// Establishes tor connection for tcp
-func newTorConn(addr string, port string, timeout int) *http.Client {
-	var torProxy = "socks5://" + addr + ":" + port
-	torProxyURL, err := urllib.Parse(torProxy)
+//export GetLinks
+func GetLinks(searchURL string, addr string, port string, timeout int) {
+	emitter := output.NewTextEmitter(os.Stdout, true)
+	result, err := crawl(searchURL, addr, port, timeout, emitter, "")
 	if err != nil {
-		log.Fatal("Error parsing URL: ", err)
+		log.Fatal("Error crawling: ", err)
 	}
-	torTransport := &http.Transport{Proxy: http.ProxyURL(torProxyURL)}
-	return &http.Client{Transport: torTransport, Timeout: time.Second * time.Duration(timeout)}
+	fmt.Printf("Number of URLs found: %v\n", len(result.Edges))
 }
 
-//export GetLinks
-func GetLinks(searchURL string, addr string, port string, timeout int) {
-	var client = newTorConn(addr, port, timeout)
-	resp, err := client.Get(searchURL)
+// GetLinksJSON runs the same crawl as GetLinks but writes the discovered
+// page/edge graph as JSON to outPath, so Python callers can consume a
+// structured result instead of parsing stdout. It returns 0 on success
+// and a non-zero status code on failure.
+//
+//export GetLinksJSON
+func GetLinksJSON(searchURL string, addr string, port string, timeout int, outPath string) int {
+	f, err := os.Create(outPath)
 	if err != nil {
-		log.Fatal("Error with GET request", err)
+		log.Print("Error creating output file: ", err)
+		return 1
 	}
-	defer resp.Body.Close()
-	tokenizer := html.NewTokenizer(resp.Body)
-	var urls []string
-	var found []string
-	for notEnd := true; notEnd; {
-		currentTokenType := tokenizer.Next()
-		switch {
-		case currentTokenType == html.ErrorToken:
-			notEnd = false
-		case currentTokenType == html.StartTagToken:
-			token := tokenizer.Token()
-			if token.Data == "a" {
-				attributes := token.Attr
-				found = parseAttrs(attributes)
-				urls = append(urls, found...)
-			}
-		}
+
+	emitter := output.NewJSONEmitter(f)
+	_, err = crawl(searchURL, addr, port, timeout, emitter, "")
+	if closeErr := emitter.Close(); closeErr != nil && err == nil {
+		err = closeErr
 	}
-	sig := make(chan os.Signal, 1)
-	ch := make(chan string)
-	signal.Notify(sig, os.Interrupt)
-	fmt.Printf("Number of URLs found: %v\n", len(urls))
-	if len(urls) == 0 {
-		os.Exit(0)
+	if err != nil {
+		log.Print("Error crawling: ", err)
+		return 2
 	}
-	fmt.Println("_____________________________")
+	return 0
+}
 
-	for _, url := range urls {
-		_, err := urllib.ParseRequestURI(url)
-		if err != nil {
-			continue
-		}
-		select {
-		case <-sig:
-			os.Exit(0)
-		default:
-			go checkURL(client, url, ch)
-		}
+// GetLinksJSONResumable runs the same crawl as GetLinksJSON, but persists
+// the crawl's frontier and visited set to a BoltDB file at storePath as it
+// runs, resuming from that file if it already holds a prior run's state.
+// This makes crawls that would otherwise take many timeout windows to
+// finish, or that get interrupted, durable across restarts. It returns 0
+// on success and a non-zero status code on failure.
+//
+//export GetLinksJSONResumable
+func GetLinksJSONResumable(searchURL string, addr string, port string, timeout int, outPath string, storePath string) int {
+	f, err := os.Create(outPath)
+	if err != nil {
+		log.Print("Error creating output file: ", err)
+		return 1
 	}
 
-	for result := range ch {
-		select {
-		case <-sig:
-			os.Exit(0)
-		default:
-			fmt.Println(result)
-		}
+	emitter := output.NewJSONEmitter(f)
+	_, err = crawl(searchURL, addr, port, timeout, emitter, storePath)
+	if closeErr := emitter.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Print("Error crawling: ", err)
+		return 2
 	}
+	return 0
 }
 
 func main() {
@@ -0,0 +1,33 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+type nopWriteCloser struct{ *bytes.Buffer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestGraphMLEmitterEscapesURLsWithAmpersands(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewGraphMLEmitter(nopWriteCloser{&buf})
+
+	const link = "http://abcxyz123.onion/search?x=1&y=2"
+	e.EmitPage(Page{URL: link, Title: "a & b"})
+	e.EmitEdge("http://abcxyz123.onion/", link, EdgeMeta{Status: 200})
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := xml.Unmarshal(buf.Bytes(), new(any)); err != nil {
+		t.Fatalf("emitted GraphML is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("x=1&y=2")) {
+		t.Errorf("URL's \"&\" was not escaped:\n%s", buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("x=1&amp;y=2")) {
+		t.Errorf("expected escaped \"&amp;\" in output:\n%s", buf.String())
+	}
+}
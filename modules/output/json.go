@@ -0,0 +1,66 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+type graph struct {
+	Pages []Page     `json:"pages"`
+	Edges []jsonEdge `json:"edges"`
+}
+
+type jsonEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Status    int    `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// jsonEmitter buffers every page and edge in memory and writes a single
+// JSON document on Close, so the output is one well-formed object rather
+// than a stream of fragments.
+type jsonEmitter struct {
+	w io.WriteCloser
+
+	mu sync.Mutex
+	g  graph
+}
+
+// NewJSONEmitter returns an Emitter that writes a single JSON object
+// (pages + edges) to w when Close is called.
+func NewJSONEmitter(w io.WriteCloser) Emitter {
+	return &jsonEmitter{w: w}
+}
+
+func (e *jsonEmitter) EmitPage(p Page) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.g.Pages = append(e.g.Pages, p)
+	return nil
+}
+
+func (e *jsonEmitter) EmitEdge(from, to string, meta EdgeMeta) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.g.Edges = append(e.g.Edges, jsonEdge{
+		From:      from,
+		To:        to,
+		Status:    meta.Status,
+		LatencyMS: meta.Latency.Milliseconds(),
+	})
+	return nil
+}
+
+func (e *jsonEmitter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(e.g); err != nil {
+		e.w.Close()
+		return err
+	}
+	return e.w.Close()
+}
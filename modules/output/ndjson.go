@@ -0,0 +1,53 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+type ndjsonRecord struct {
+	Type      string `json:"type"` // "page" or "edge"
+	Page      *Page  `json:"page,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+}
+
+// ndjsonEmitter writes one JSON object per line as soon as each page or
+// edge is emitted, so a long-running crawl can be tailed or piped rather
+// than waiting for the whole graph to be buffered.
+type ndjsonEmitter struct {
+	w   io.WriteCloser
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+// NewNDJSONEmitter returns an Emitter that writes newline-delimited JSON
+// records to w as they arrive.
+func NewNDJSONEmitter(w io.WriteCloser) Emitter {
+	return &ndjsonEmitter{w: w, enc: json.NewEncoder(w)}
+}
+
+func (e *ndjsonEmitter) EmitPage(p Page) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.enc.Encode(ndjsonRecord{Type: "page", Page: &p})
+}
+
+func (e *ndjsonEmitter) EmitEdge(from, to string, meta EdgeMeta) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.enc.Encode(ndjsonRecord{
+		Type:      "edge",
+		From:      from,
+		To:        to,
+		Status:    meta.Status,
+		LatencyMS: meta.Latency.Milliseconds(),
+	})
+}
+
+func (e *ndjsonEmitter) Close() error {
+	return e.w.Close()
+}
@@ -0,0 +1,24 @@
+// Package output turns a crawl's pages and edges into one of several
+// serialized formats, replacing ad-hoc fmt.Println calls at the call
+// site.
+package output
+
+import (
+	"github.com/DedSecInside/TorBot/modules/crawler"
+)
+
+// Page is the page type emitted by a crawl.
+type Page = crawler.Page
+
+// EdgeMeta carries the extra information known about an edge at the time
+// it is emitted.
+type EdgeMeta = crawler.EdgeMeta
+
+// Emitter receives a crawl's pages and edges as they are discovered.
+// Implementations must be safe for concurrent use, since a Crawler may
+// call EmitPage/EmitEdge from multiple workers at once.
+type Emitter interface {
+	EmitPage(Page) error
+	EmitEdge(from, to string, meta EdgeMeta) error
+	Close() error
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/mgutz/ansi"
+)
+
+// textEmitter prints one human-readable line per page as it arrives,
+// worded like the fmt.Println-based output it replaces. Edges are not
+// printed.
+type textEmitter struct {
+	w     io.WriteCloser
+	color bool
+	mu    sync.Mutex
+}
+
+// NewTextEmitter returns an Emitter that prints each page to w as a plain
+// "is reachable."/"is not reachable." line; EmitEdge writes nothing. When
+// color is true, unreachable pages are highlighted in red.
+func NewTextEmitter(w io.WriteCloser, color bool) Emitter {
+	return &textEmitter{w: w, color: color}
+}
+
+func (e *textEmitter) EmitPage(p Page) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	line := fmt.Sprintf("%v is reachable.", p.URL)
+	if p.Status <= 0 || p.Status >= 400 {
+		line = fmt.Sprintf("%v is not reachable.", p.URL)
+		if e.color {
+			line = ansi.Color(line, "red")
+		}
+	}
+	_, err := fmt.Fprintln(e.w, line)
+	return err
+}
+
+func (e *textEmitter) EmitEdge(from, to string, meta EdgeMeta) error {
+	return nil
+}
+
+func (e *textEmitter) Close() error {
+	return e.w.Close()
+}
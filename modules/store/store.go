@@ -0,0 +1,150 @@
+// Package store persists a crawl's frontier and visited set in a single
+// BoltDB file, so a crawler.Crawler can resume a long-running crawl
+// exactly where it left off after a restart instead of starting over.
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DedSecInside/TorBot/modules/crawler"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Page is the page type recorded against a visited URL.
+type Page = crawler.Page
+
+// Job is a queued URL and the depth it was discovered at.
+type Job = crawler.Job
+
+// Store persists a crawl's frontier and visited set.
+type Store = crawler.Store
+
+var (
+	frontierBucket = []byte("frontier")
+	visitedBucket  = []byte("visited")
+)
+
+// BoltStore is a Store backed by a BoltDB file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if necessary) a BoltDB-backed Store at path.
+func Open(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("store: opening %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(frontierBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(visitedBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: initializing %s: %w", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Load opens the store at path and returns a Crawler primed to resume
+// it: Run will drain this store's frontier and consult its visited set
+// before seeding from scratch. Callers still need to set Client and any
+// other crawl options before calling Run.
+func Load(path string) (*crawler.Crawler, error) {
+	st, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &crawler.Crawler{Store: st}, nil
+}
+
+// frontierEntry is the on-disk representation of a queued Job.
+type frontierEntry struct {
+	URL   string
+	Depth int
+}
+
+// Enqueue adds url to the on-disk frontier at depth.
+func (s *BoltStore) Enqueue(url string, depth int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(frontierBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(frontierEntry{URL: url, Depth: depth})
+		if err != nil {
+			return err
+		}
+		return b.Put(seqKey(seq), data)
+	})
+}
+
+// Dequeue removes and returns the oldest queued Job, if any.
+func (s *BoltStore) Dequeue() (Job, bool, error) {
+	var j Job
+	var found bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(frontierBucket)
+		k, v := b.Cursor().First()
+		if k == nil {
+			return nil
+		}
+		var entry frontierEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return err
+		}
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		j = Job{URL: entry.URL, Depth: entry.Depth}
+		found = true
+		return nil
+	})
+	return j, found, err
+}
+
+// MarkVisited records that url has been fetched, storing page so it can
+// be inspected or resumed from later.
+func (s *BoltStore) MarkVisited(url string, page Page) error {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(visitedBucket).Put([]byte(url), data)
+	})
+}
+
+// IsVisited reports whether url has already been recorded via MarkVisited.
+func (s *BoltStore) IsVisited(url string) (bool, error) {
+	var visited bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		visited = tx.Bucket(visitedBucket).Get([]byte(url)) != nil
+		return nil
+	})
+	return visited, err
+}
+
+// Checkpoint flushes the database file to disk.
+func (s *BoltStore) Checkpoint() error {
+	return s.db.Sync()
+}
+
+// Close releases the underlying database file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// seqKey encodes a bucket sequence number as a fixed-width big-endian
+// key, so the frontier bucket's natural key order is FIFO order.
+func seqKey(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *BoltStore {
+	t.Helper()
+	st, err := Open(filepath.Join(t.TempDir(), "crawl.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func TestEnqueueDequeueFIFO(t *testing.T) {
+	st := openTestStore(t)
+
+	if err := st.Enqueue("http://a.onion", 0); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := st.Enqueue("http://b.onion", 1); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	first, ok, err := st.Dequeue()
+	if err != nil || !ok {
+		t.Fatalf("Dequeue() = %v, %v, %v", first, ok, err)
+	}
+	if first.URL != "http://a.onion" || first.Depth != 0 {
+		t.Errorf("Dequeue() = %+v, want {http://a.onion 0}", first)
+	}
+
+	second, ok, err := st.Dequeue()
+	if err != nil || !ok {
+		t.Fatalf("Dequeue() = %v, %v, %v", second, ok, err)
+	}
+	if second.URL != "http://b.onion" {
+		t.Errorf("Dequeue() = %+v, want URL http://b.onion", second)
+	}
+
+	if _, ok, err := st.Dequeue(); err != nil || ok {
+		t.Errorf("Dequeue() on empty store = ok %v, err %v, want ok=false", ok, err)
+	}
+}
+
+func TestMarkVisitedIsVisited(t *testing.T) {
+	st := openTestStore(t)
+
+	if visited, err := st.IsVisited("http://a.onion"); err != nil || visited {
+		t.Fatalf("IsVisited() before MarkVisited = %v, %v, want false", visited, err)
+	}
+
+	if err := st.MarkVisited("http://a.onion", Page{URL: "http://a.onion", Status: 200}); err != nil {
+		t.Fatalf("MarkVisited() error = %v", err)
+	}
+
+	visited, err := st.IsVisited("http://a.onion")
+	if err != nil {
+		t.Fatalf("IsVisited() error = %v", err)
+	}
+	if !visited {
+		t.Errorf("IsVisited() = false after MarkVisited, want true")
+	}
+}
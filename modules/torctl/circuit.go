@@ -0,0 +1,199 @@
+package torctl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// signalNewnym is the control-port signal that asks Tor to stop using
+// existing circuits for new streams. The bine control package doesn't
+// export a constant for it, so CircuitManager sends the literal command.
+const signalNewnym = "NEWNYM"
+
+// CircuitPolicy controls how aggressively a CircuitManager isolates and
+// rotates the Tor circuits backing its HTTP clients. Reusing one exit
+// node across a whole crawl makes onion sites easy to rate-limit, so a
+// CircuitManager spreads requests across many circuits instead.
+type CircuitPolicy struct {
+	// IsolatePerHost gives every distinct host its own circuit, via a
+	// unique SOCKS auth tuple, so exit nodes are never shared between
+	// hosts being crawled concurrently.
+	IsolatePerHost bool
+	// RotateEvery rotates a circuit's auth tuple after this many
+	// requests. Zero disables request-count rotation.
+	RotateEvery int
+	// RotateOn forces a circuit's rotation, plus a NEWNYM signal, the
+	// moment a response carries one of these HTTP status codes, e.g.
+	// 429 or 503.
+	RotateOn []int
+	// MaxFailuresPerCircuit forces a circuit's rotation, plus a NEWNYM
+	// signal, after this many consecutive transport errors. Zero
+	// disables failure-based rotation.
+	MaxFailuresPerCircuit int
+}
+
+// CircuitMetrics summarizes how a CircuitManager has rotated circuits,
+// so operators can tune CircuitPolicy for a given crawl.
+type CircuitMetrics struct {
+	CircuitsCreated   int64
+	NewnymCount       int64
+	RotationsByStatus map[int]int64
+}
+
+// CircuitManager hands out *http.Clients isolated per CircuitPolicy and
+// rotates their underlying circuits as they're used.
+type CircuitManager struct {
+	instance *Instance
+	policy   CircuitPolicy
+
+	circuitsCreated int64
+	newnymCount     int64
+
+	mu                sync.Mutex
+	rotationsByStatus map[int]int64
+
+	circuits sync.Map // key -> *circuit
+}
+
+// NewCircuitManager returns a CircuitManager handing out clients backed
+// by instance, rotated according to policy.
+func NewCircuitManager(instance *Instance, policy CircuitPolicy) *CircuitManager {
+	return &CircuitManager{
+		instance:          instance,
+		policy:            policy,
+		rotationsByStatus: make(map[int]int64),
+	}
+}
+
+// circuit tracks one isolated client's usage so a CircuitManager knows
+// when it needs rotating.
+type circuit struct {
+	mu         sync.Mutex
+	client     *http.Client
+	generation int
+	requests   int
+	failures   int
+}
+
+// Client returns the *http.Client to use for key, typically a host.
+// With CircuitPolicy.IsolatePerHost set, each distinct key gets its own
+// circuit; otherwise every caller shares one.
+func (cm *CircuitManager) Client(ctx context.Context, key string) (*http.Client, error) {
+	if !cm.policy.IsolatePerHost {
+		key = "shared"
+	}
+	v, _ := cm.circuits.LoadOrStore(key, &circuit{})
+	circ := v.(*circuit)
+
+	circ.mu.Lock()
+	defer circ.mu.Unlock()
+	if circ.client == nil {
+		if err := cm.buildLocked(ctx, key, circ); err != nil {
+			return nil, err
+		}
+	}
+	return circ.client, nil
+}
+
+// buildLocked builds circ's client under a fresh SOCKS auth tuple, which
+// Tor treats as a new, isolated circuit. circ.mu must be held.
+func (cm *CircuitManager) buildLocked(ctx context.Context, key string, circ *circuit) error {
+	circ.generation++
+	streamID := fmt.Sprintf("%s-%d", key, circ.generation)
+	circ.requests = 0
+	circ.failures = 0
+
+	client, err := cm.instance.IsolatedHTTPClient(ctx, streamID)
+	if err != nil {
+		return err
+	}
+	client.Transport = &rotatingTransport{base: client.Transport, cm: cm, circ: circ}
+	circ.client = client
+	atomic.AddInt64(&cm.circuitsCreated, 1)
+	return nil
+}
+
+// rotate drops circ's current client, so the next Client call builds a
+// fresh one under a new auth tuple, and optionally signals NEWNYM so Tor
+// also abandons the underlying circuit rather than just the stream.
+func (cm *CircuitManager) rotate(circ *circuit, sendNewnym bool) {
+	if sendNewnym {
+		if err := cm.instance.Signal(signalNewnym); err == nil {
+			atomic.AddInt64(&cm.newnymCount, 1)
+		}
+	}
+	circ.mu.Lock()
+	circ.client = nil
+	circ.mu.Unlock()
+}
+
+// recordStatusRotation counts a rotation triggered by CircuitPolicy.RotateOn.
+func (cm *CircuitManager) recordStatusRotation(status int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.rotationsByStatus[status]++
+}
+
+// Metrics returns a snapshot of the rotations this CircuitManager has
+// performed so far.
+func (cm *CircuitManager) Metrics() CircuitMetrics {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	byStatus := make(map[int]int64, len(cm.rotationsByStatus))
+	for status, count := range cm.rotationsByStatus {
+		byStatus[status] = count
+	}
+	return CircuitMetrics{
+		CircuitsCreated:   atomic.LoadInt64(&cm.circuitsCreated),
+		NewnymCount:       atomic.LoadInt64(&cm.newnymCount),
+		RotationsByStatus: byStatus,
+	}
+}
+
+// rotatingTransport wraps a circuit's RoundTripper to count its requests
+// and trigger rotation once CircuitPolicy's thresholds are hit.
+type rotatingTransport struct {
+	base http.RoundTripper
+	cm   *CircuitManager
+	circ *circuit
+}
+
+func (t *rotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+
+	t.circ.mu.Lock()
+	t.circ.requests++
+	if err != nil {
+		t.circ.failures++
+	} else {
+		t.circ.failures = 0
+	}
+	policy := t.cm.policy
+	onFailureLimit := policy.MaxFailuresPerCircuit > 0 && t.circ.failures >= policy.MaxFailuresPerCircuit
+	onRequestLimit := policy.RotateEvery > 0 && t.circ.requests >= policy.RotateEvery
+	statusHit := 0
+	if resp != nil {
+		for _, code := range policy.RotateOn {
+			if resp.StatusCode == code {
+				statusHit = code
+				break
+			}
+		}
+	}
+	t.circ.mu.Unlock()
+
+	switch {
+	case statusHit != 0:
+		t.cm.rotate(t.circ, true)
+		t.cm.recordStatusRotation(statusHit)
+	case onFailureLimit:
+		t.cm.rotate(t.circ, true)
+	case onRequestLimit:
+		t.cm.rotate(t.circ, false)
+	}
+
+	return resp, err
+}
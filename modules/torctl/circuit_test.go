@@ -0,0 +1,87 @@
+package torctl
+
+import (
+	"net/http"
+	"testing"
+)
+
+type fakeRoundTripper struct {
+	resp *http.Response
+	err  error
+}
+
+func (f *fakeRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return f.resp, f.err
+}
+
+func newTestRequest(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://example.onion", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	return req
+}
+
+// RotateEvery rotation doesn't signal NEWNYM, so it's safe to exercise
+// without a live Instance.
+func TestRotatingTransportRotatesOnRequestLimit(t *testing.T) {
+	cm := NewCircuitManager(nil, CircuitPolicy{RotateEvery: 2})
+	circ := &circuit{client: &http.Client{}}
+	rt := &rotatingTransport{base: &fakeRoundTripper{resp: &http.Response{StatusCode: 200}}, cm: cm, circ: circ}
+	req := newTestRequest(t)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if circ.client == nil {
+		t.Fatalf("circuit rotated before RotateEvery requests were made")
+	}
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if circ.client != nil {
+		t.Errorf("circuit not rotated after RotateEvery requests were made")
+	}
+}
+
+func TestRotatingTransportTracksFailures(t *testing.T) {
+	cm := NewCircuitManager(nil, CircuitPolicy{MaxFailuresPerCircuit: 5})
+	circ := &circuit{client: &http.Client{}}
+	rt := &rotatingTransport{base: &fakeRoundTripper{err: errDial}, cm: cm, circ: circ}
+	req := newTestRequest(t)
+
+	rt.RoundTrip(req)
+	rt.RoundTrip(req)
+	if circ.failures != 2 {
+		t.Fatalf("circ.failures = %d, want 2", circ.failures)
+	}
+
+	rt.base = &fakeRoundTripper{resp: &http.Response{StatusCode: 200}}
+	rt.RoundTrip(req)
+	if circ.failures != 0 {
+		t.Errorf("circ.failures = %d after a success, want 0", circ.failures)
+	}
+}
+
+func TestCircuitManagerRecordStatusRotation(t *testing.T) {
+	cm := NewCircuitManager(nil, CircuitPolicy{RotateOn: []int{429, 503}})
+	cm.recordStatusRotation(429)
+	cm.recordStatusRotation(429)
+	cm.recordStatusRotation(503)
+
+	metrics := cm.Metrics()
+	if metrics.RotationsByStatus[429] != 2 {
+		t.Errorf("RotationsByStatus[429] = %d, want 2", metrics.RotationsByStatus[429])
+	}
+	if metrics.RotationsByStatus[503] != 1 {
+		t.Errorf("RotationsByStatus[503] = %d, want 1", metrics.RotationsByStatus[503])
+	}
+}
+
+var errDial = &dialError{"dial failed"}
+
+type dialError struct{ msg string }
+
+func (e *dialError) Error() string { return e.msg }
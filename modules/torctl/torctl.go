@@ -0,0 +1,231 @@
+// Package torctl runs an embedded Tor process via bine so TorBot no longer
+// depends on a system `tor` daemon listening on a SOCKS port. It also
+// exposes the control port so callers can manage circuits directly.
+package torctl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cretz/bine/control"
+	"github.com/cretz/bine/tor"
+	"golang.org/x/net/proxy"
+)
+
+// Config controls how the embedded Tor instance is bootstrapped.
+type Config struct {
+	// DataDir is where Tor keeps its state between runs. An empty value
+	// uses a temporary directory that is removed on Close.
+	DataDir string
+	// Bridges are optional "bridge" lines used to reach the Tor network
+	// from a censored network.
+	Bridges []string
+	// ControlPort pins the control port Tor listens on; 0 picks a free
+	// port automatically.
+	ControlPort int
+	// SocksPort pins the address (e.g. "127.0.0.1:9050") the embedded
+	// instance's SOCKS proxy listens on; empty picks a free port
+	// automatically. Useful when other tooling expects Tor reachable at a
+	// fixed address.
+	SocksPort string
+	// ControlCookieAuth is not read by Start; control port authentication
+	// is left to bine's defaults whatever its value.
+	ControlCookieAuth bool
+	// Debug streams Tor's own log output to the process's stderr.
+	Debug bool
+}
+
+// Instance wraps a running, embedded Tor process along with its control
+// port connection.
+type Instance struct {
+	t   *tor.Tor
+	cfg Config
+}
+
+// Start bootstraps an embedded Tor process and waits for it to finish
+// establishing circuits. The returned Instance must be closed with Close
+// once the caller is done with it.
+func Start(ctx context.Context, cfg Config) (*Instance, error) {
+	startConf := &tor.StartConf{
+		DataDir:           cfg.DataDir,
+		TempDataDirBase:   "",
+		RetainTempDataDir: cfg.DataDir != "",
+		NoAutoSocksPort:   false,
+		DebugWriter:       nil,
+	}
+	if cfg.Debug {
+		startConf.DebugWriter = os.Stderr
+	}
+	if cfg.ControlPort != 0 {
+		startConf.ExtraArgs = append(startConf.ExtraArgs, "ControlPort", fmt.Sprintf("%d", cfg.ControlPort))
+	}
+	if cfg.SocksPort != "" {
+		startConf.ExtraArgs = append(startConf.ExtraArgs, "SocksPort", cfg.SocksPort)
+	}
+	for _, bridge := range cfg.Bridges {
+		startConf.ExtraArgs = append(startConf.ExtraArgs, "Bridge", bridge)
+		startConf.ExtraArgs = append(startConf.ExtraArgs, "UseBridges", "1")
+	}
+
+	t, err := tor.Start(ctx, startConf)
+	if err != nil {
+		return nil, fmt.Errorf("starting embedded tor: %w", err)
+	}
+
+	bootstrapCtx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+	defer cancel()
+	if err := t.EnableNetwork(bootstrapCtx, true); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("bootstrapping tor network: %w", err)
+	}
+
+	return &Instance{t: t, cfg: cfg}, nil
+}
+
+// Close shuts down the embedded Tor process and releases its resources.
+func (i *Instance) Close() error {
+	return i.t.Close()
+}
+
+// Dialer returns a dialer that routes connections through the embedded
+// Tor instance over its own, non-isolated stream.
+func (i *Instance) Dialer(ctx context.Context) (*tor.Dialer, error) {
+	return i.t.Dialer(ctx, nil)
+}
+
+// IsolatedDialer returns a dialer whose connections use a dedicated SOCKS
+// auth tuple, which Tor treats as an isolated circuit from every other
+// dialer's streams. streamID should be unique per caller (e.g. per host
+// or per worker) so that streams are not accidentally shared.
+func (i *Instance) IsolatedDialer(ctx context.Context, streamID string) (*tor.Dialer, error) {
+	return i.t.Dialer(ctx, &tor.DialConf{
+		SkipEnableNetwork: true,
+		ProxyAuth:         &proxy.Auth{User: streamID, Password: streamID},
+	})
+}
+
+// HTTPClient returns an *http.Client that routes all requests through the
+// embedded Tor instance on a shared, non-isolated stream.
+func (i *Instance) HTTPClient(ctx context.Context) (*http.Client, error) {
+	dialer, err := i.Dialer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}, nil
+}
+
+// IsolatedHTTPClient returns an *http.Client whose connections run on a
+// circuit isolated from every other client produced by this Instance.
+func (i *Instance) IsolatedHTTPClient(ctx context.Context, streamID string) (*http.Client, error) {
+	dialer, err := i.IsolatedDialer(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}, nil
+}
+
+// control returns the instance's authenticated control port connection.
+func (i *Instance) control() (*control.Conn, error) {
+	return i.t.Control, nil
+}
+
+// NewCircuit asks Tor to build a new general-purpose circuit and returns
+// its circuit ID.
+func (i *Instance) NewCircuit() (string, error) {
+	conn, err := i.control()
+	if err != nil {
+		return "", err
+	}
+	resp, err := conn.SendRequest("EXTENDCIRCUIT 0 purpose=general")
+	if err != nil {
+		return "", fmt.Errorf("extending circuit: %w", err)
+	}
+	// Tor replies "EXTENDED <CircuitID>"; keep only the ID.
+	return strings.TrimPrefix(resp.Reply, "EXTENDED "), nil
+}
+
+// CloseCircuit tears down the circuit with the given ID.
+func (i *Instance) CloseCircuit(id string) error {
+	conn, err := i.control()
+	if err != nil {
+		return err
+	}
+	_, err = conn.SendRequest("CLOSECIRCUIT %s", id)
+	return err
+}
+
+// Signal sends a control-port signal such as "NEWNYM" to the embedded
+// Tor process.
+func (i *Instance) Signal(signal string) error {
+	conn, err := i.control()
+	if err != nil {
+		return err
+	}
+	return conn.Signal(signal)
+}
+
+// OnionConfig describes a hidden service to publish via Publish.
+type OnionConfig struct {
+	// Version is the onion service version; only v3 (the default, 0) is
+	// supported by modern Tor.
+	Version int
+	// RemotePorts are the virtual ports clients connect to; each is
+	// forwarded to LocalPort. An empty slice defaults to LocalPort.
+	RemotePorts []int
+	// LocalPort is the local port the published listener accepts
+	// connections on; 0 picks a free port automatically. bine's
+	// underlying Listen call forwards every RemotePort to this single
+	// local target, so distinct local ports per remote port aren't
+	// supported by one Publish call.
+	LocalPort int
+	// Persist detaches the service from the control connection, so Tor
+	// keeps it after that connection closes. No private key is written
+	// to disk: each Publish call generates a new key and .onion address.
+	Persist bool
+}
+
+// OnionService is a published hidden service and the listener backing it.
+type OnionService struct {
+	ID       string
+	Listener net.Listener
+
+	onion *tor.OnionService
+}
+
+// Close removes the hidden service and closes its listener.
+func (o *OnionService) Close() error {
+	return o.onion.Close()
+}
+
+// Publish creates a hidden service and returns the listener backing it.
+// Publish starts no server itself; a caller can serve e.g. a local HTTP
+// status server on the listener, letting a crawl be inspected or
+// controlled remotely without punching a hole in any firewall.
+func (i *Instance) Publish(ctx context.Context, cfg OnionConfig) (*OnionService, error) {
+	listenConf := &tor.ListenConf{
+		Version3:    cfg.Version != 2,
+		RemotePorts: cfg.RemotePorts,
+		LocalPort:   cfg.LocalPort,
+		Detach:      cfg.Persist,
+	}
+
+	onion, err := i.t.Listen(ctx, listenConf)
+	if err != nil {
+		return nil, fmt.Errorf("publishing onion service: %w", err)
+	}
+
+	return &OnionService{
+		ID:       onion.ID,
+		Listener: onion,
+		onion:    onion,
+	}, nil
+}